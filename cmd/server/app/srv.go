@@ -4,13 +4,24 @@ import (
 	"fmt"
 	"log"
 
+	ac "github.com/echenim/barter/internal/accounting"
 	hdl "github.com/echenim/barter/internal/handlers"
+	md "github.com/echenim/barter/internal/models"
+	"github.com/echenim/barter/internal/risk"
+	strm "github.com/echenim/barter/internal/stream"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/labstack/echo/v4"
 )
 
 const exchangePrivateKey = "4f3edf983ac636a65a842ce7c78d9aa706d3b113bce9c46f30d7d21715b23b1d"
 
+// riskConfigPath is where operators can tune risk limits without a rebuild.
+const riskConfigPath = "risk.yaml"
+
+// positionsPath is where per-user position snapshots are persisted so
+// restarts don't lose accounting history.
+const positionsPath = "positions.json"
+
 func StartServer() {
 	e := echo.New()
 	e.HTTPErrorHandler = httpErrorHandler
@@ -20,7 +31,7 @@ func StartServer() {
 		log.Fatal(err)
 	}
 
-	ex, err := hdl.NewExchange(exchangePrivateKey, client)
+	ex, err := hdl.NewExchange(exchangePrivateKey, client, []md.MarketConfig{hdl.DefaultETHMarket()})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -29,15 +40,36 @@ func StartServer() {
 	ex.RegisterUser("a453611d9419d0e56f499079478fd72c37b251a94bfde4d19872c44cf65386e3", 7)
 	ex.RegisterUser("e485d098507f54e7733a205420dfddbe58db035fa577fc294ebd14db90767a52", 666)
 
+	riskCfg, err := risk.LoadConfig(riskConfigPath)
+	if err != nil {
+		log.Printf("risk: no config at %s, starting with no risk limits: %v", riskConfigPath, err)
+		riskCfg = &risk.Config{}
+	}
+	ex.UseRiskGuard(risk.NewGuard(*riskCfg))
+
+	tracker, err := ac.NewTracker(ac.NewFileStore(positionsPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ex.UseAccounting(tracker)
+
 	e.POST("/order", ex.PlaceOrder)
+	e.POST("/orders/batch", ex.BatchPlaceOrders)
+	e.DELETE("/orders/batch", ex.BatchCancelOrders)
+	e.POST("/admin/markets", ex.RegisterMarket)
+	e.POST("/admin/risk/reset", ex.ResetRisk)
+	e.POST("/admin/risk/kill-switch", ex.SetKillSwitch)
 
 	e.GET("/trades/:market", ex.GetTrades)
 	e.GET("/order/:userID", ex.GetOrders)
 	e.GET("/book/:market", ex.GetBook)
 	e.GET("/book/:market/bid", ex.GetBestBid)
 	e.GET("/book/:market/ask", ex.GetBestAsk)
+	e.GET("/pnl/:userID", ex.GetPnL)
+
+	e.DELETE("/order/:market/:id", ex.CancelBid)
 
-	e.DELETE("/order/:id", ex.CancelOrder)
+	strm.RegisterRoutes(e, ex)
 
 	e.Start(":3000")
 }
@@ -0,0 +1,18 @@
+package accounting
+
+// Position tracks a single user's weighted-average cost basis and net size
+// in a single market. NetSize is positive for a net-long position, negative
+// for net-short, and zero when flat.
+type Position struct {
+	Market      string
+	NetSize     float64
+	AvgCost     float64
+	RealizedPnL float64
+	FeesPaid    float64
+	LastPrice   float64
+}
+
+// UnrealizedPnL returns p's mark-to-market PnL against its last trade price.
+func (p Position) UnrealizedPnL() float64 {
+	return p.NetSize * (p.LastPrice - p.AvgCost)
+}
@@ -0,0 +1,57 @@
+package accounting
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// Store persists a snapshot of every user's positions so a Tracker can
+// recover its state across restarts. A SQL-backed Store can slot in later
+// behind the same interface.
+type Store interface {
+	// Load returns the last persisted snapshot, keyed by user ID then market.
+	// It returns an empty (not nil) map if no snapshot has been saved yet.
+	Load() (map[int64]map[string]*Position, error)
+	// Save overwrites the persisted snapshot with positions.
+	Save(positions map[int64]map[string]*Position) error
+}
+
+// FileStore persists position snapshots as a single JSON file at Path.
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore creates a FileStore persisting to path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the snapshot from s.Path, returning an empty map if the file
+// doesn't exist yet (e.g. on first run).
+func (s *FileStore) Load() (map[int64]map[string]*Position, error) {
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[int64]map[string]*Position), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[int64]map[string]*Position)
+	if err := json.Unmarshal(raw, &positions); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// Save writes positions to s.Path as JSON, overwriting any existing snapshot.
+func (s *FileStore) Save(positions map[int64]map[string]*Position) error {
+	raw, err := json.MarshalIndent(positions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.Path, raw, 0644)
+}
@@ -0,0 +1,158 @@
+package accounting
+
+import (
+	"math"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tracker maintains per-user, per-market positions by applying fills as they
+// happen, and persists a snapshot through a Store after every fill so
+// restarts don't lose history.
+type Tracker struct {
+	mu sync.Mutex
+
+	store     Store
+	positions map[int64]map[string]*Position
+}
+
+// NewTracker creates a Tracker backed by store, restoring any previously
+// persisted positions.
+func NewTracker(store Store) (*Tracker, error) {
+	positions, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracker{store: store, positions: positions}, nil
+}
+
+// RecordFill applies a single fill of size (always positive) at price to
+// userID's position in market, updating the weighted-average cost basis and
+// realized PnL, then persists the updated snapshot. bid is true for a buy
+// fill, false for a sell.
+//
+// For a fill that grows the position (or opens one from flat), the average
+// cost becomes the size-weighted blend of the old and new cost:
+// new_avg = (old_avg*old_size + price*size) / (old_size + size). For a fill
+// that reduces or reverses the position, the portion that closes the
+// existing position realizes (price - old_avg) * closedSize, sign-flipped
+// for a short position; any remainder beyond a full close opens a fresh
+// position at price.
+func (t *Tracker) RecordFill(userID int64, market string, bid bool, size, price, fee float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pos := t.positionLocked(userID, market)
+	pos.LastPrice = price
+	pos.FeesPaid += fee
+
+	delta := size
+	if !bid {
+		delta = -size
+	}
+
+	if pos.NetSize == 0 || sameSign(pos.NetSize, delta) {
+		pos.AvgCost = (pos.AvgCost*math.Abs(pos.NetSize) + price*math.Abs(delta)) / (math.Abs(pos.NetSize) + math.Abs(delta))
+		pos.NetSize += delta
+	} else {
+		closing := math.Min(math.Abs(delta), math.Abs(pos.NetSize))
+		sign := 1.0
+		if pos.NetSize < 0 {
+			sign = -1.0
+		}
+		pos.RealizedPnL += sign * (price - pos.AvgCost) * closing
+
+		pos.NetSize += delta
+		switch {
+		case pos.NetSize == 0:
+			pos.AvgCost = 0
+		case sameSign(pos.NetSize, delta):
+			// delta more than closed the prior position, reversing its
+			// direction; the remainder opens a fresh position at price.
+			pos.AvgCost = price
+		}
+	}
+
+	t.persistLocked()
+}
+
+// PnL returns userID's realized/unrealized PnL, fees paid, and per-market
+// breakdown across every market they've traded.
+func (t *Tracker) PnL(userID int64) UserPnL {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := UserPnL{UserID: userID, Markets: make(map[string]MarketPnL)}
+
+	for market, pos := range t.positions[userID] {
+		unrealized := pos.UnrealizedPnL()
+
+		result.RealizedPnL += pos.RealizedPnL
+		result.UnrealizedPnL += unrealized
+		result.FeesPaid += pos.FeesPaid
+
+		result.Markets[market] = MarketPnL{
+			NetSize:       pos.NetSize,
+			AvgCost:       pos.AvgCost,
+			LastPrice:     pos.LastPrice,
+			RealizedPnL:   pos.RealizedPnL,
+			UnrealizedPnL: unrealized,
+			FeesPaid:      pos.FeesPaid,
+		}
+	}
+
+	return result
+}
+
+// positionLocked returns userID's position in market, creating it if this is
+// their first fill there. Callers must hold t.mu.
+func (t *Tracker) positionLocked(userID int64, market string) *Position {
+	markets, ok := t.positions[userID]
+	if !ok {
+		markets = make(map[string]*Position)
+		t.positions[userID] = markets
+	}
+
+	pos, ok := markets[market]
+	if !ok {
+		pos = &Position{Market: market}
+		markets[market] = pos
+	}
+
+	return pos
+}
+
+// persistLocked saves the current snapshot through t.store, logging rather
+// than failing the fill if the write itself fails. Callers must hold t.mu.
+func (t *Tracker) persistLocked() {
+	if err := t.store.Save(t.positions); err != nil {
+		logrus.WithError(err).Error("accounting: failed to persist position snapshot")
+	}
+}
+
+// sameSign reports whether a and b are both positive or both negative.
+func sameSign(a, b float64) bool {
+	return (a > 0 && b > 0) || (a < 0 && b < 0)
+}
+
+// UserPnL is a user's PnL and position breakdown across every market they've
+// traded, as returned by GET /pnl/:userID.
+type UserPnL struct {
+	UserID        int64
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	FeesPaid      float64
+	Markets       map[string]MarketPnL
+}
+
+// MarketPnL is a user's PnL breakdown within a single market.
+type MarketPnL struct {
+	NetSize       float64
+	AvgCost       float64
+	LastPrice     float64
+	RealizedPnL   float64
+	UnrealizedPnL float64
+	FeesPaid      float64
+}
@@ -0,0 +1,159 @@
+package accounting
+
+import (
+	"math"
+	"testing"
+)
+
+// memStore is a Store that never touches disk, so tests can assert on
+// Tracker's in-memory math without a FileStore.
+type memStore struct{}
+
+func (memStore) Load() (map[int64]map[string]*Position, error) {
+	return make(map[int64]map[string]*Position), nil
+}
+
+func (memStore) Save(positions map[int64]map[string]*Position) error {
+	return nil
+}
+
+func newTestTracker(t *testing.T) *Tracker {
+	t.Helper()
+
+	tr, err := NewTracker(memStore{})
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+
+	return tr
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTracker_RecordFill_GrowingPosition(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 1, 100, 0)
+	tr.RecordFill(1, "ETH-USD", true, 1, 200, 0)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.NetSize, 2) {
+		t.Errorf("NetSize = %v, want 2", pos.NetSize)
+	}
+	if !almostEqual(pos.AvgCost, 150) {
+		t.Errorf("AvgCost = %v, want 150", pos.AvgCost)
+	}
+	if !almostEqual(pos.RealizedPnL, 0) {
+		t.Errorf("RealizedPnL = %v, want 0", pos.RealizedPnL)
+	}
+}
+
+func TestTracker_RecordFill_PartialClose(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 2, 100, 0)
+	tr.RecordFill(1, "ETH-USD", false, 1, 150, 0)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.NetSize, 1) {
+		t.Errorf("NetSize = %v, want 1", pos.NetSize)
+	}
+	if !almostEqual(pos.AvgCost, 100) {
+		t.Errorf("AvgCost = %v, want 100 (unchanged by a partial close)", pos.AvgCost)
+	}
+	if !almostEqual(pos.RealizedPnL, 50) {
+		t.Errorf("RealizedPnL = %v, want 50", pos.RealizedPnL)
+	}
+}
+
+func TestTracker_RecordFill_FullClose(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 1, 100, 0)
+	tr.RecordFill(1, "ETH-USD", false, 1, 120, 0)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.NetSize, 0) {
+		t.Errorf("NetSize = %v, want 0", pos.NetSize)
+	}
+	if !almostEqual(pos.AvgCost, 0) {
+		t.Errorf("AvgCost = %v, want 0 once flat", pos.AvgCost)
+	}
+	if !almostEqual(pos.RealizedPnL, 20) {
+		t.Errorf("RealizedPnL = %v, want 20", pos.RealizedPnL)
+	}
+}
+
+func TestTracker_RecordFill_ReversingPosition(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 1, 100, 0)
+	tr.RecordFill(1, "ETH-USD", false, 3, 120, 0)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.NetSize, -2) {
+		t.Errorf("NetSize = %v, want -2", pos.NetSize)
+	}
+	if !almostEqual(pos.AvgCost, 120) {
+		t.Errorf("AvgCost = %v, want 120 (the fresh position opened by the reversal)", pos.AvgCost)
+	}
+	if !almostEqual(pos.RealizedPnL, 20) {
+		t.Errorf("RealizedPnL = %v, want 20", pos.RealizedPnL)
+	}
+}
+
+func TestTracker_RecordFill_ShortPositionRealizesPnLOnTheOppositeSign(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", false, 1, 100, 0)
+	tr.RecordFill(1, "ETH-USD", true, 1, 80, 0)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.NetSize, 0) {
+		t.Errorf("NetSize = %v, want 0", pos.NetSize)
+	}
+	if !almostEqual(pos.RealizedPnL, 20) {
+		t.Errorf("RealizedPnL = %v, want 20 (short covered below entry)", pos.RealizedPnL)
+	}
+}
+
+func TestTracker_RecordFill_TracksFeesAndLastPrice(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 1, 100, 0.5)
+	tr.RecordFill(1, "ETH-USD", true, 1, 110, 0.25)
+
+	pos := tr.positionLocked(1, "ETH-USD")
+	if !almostEqual(pos.FeesPaid, 0.75) {
+		t.Errorf("FeesPaid = %v, want 0.75", pos.FeesPaid)
+	}
+	if !almostEqual(pos.LastPrice, 110) {
+		t.Errorf("LastPrice = %v, want 110", pos.LastPrice)
+	}
+}
+
+func TestTracker_PnL_AggregatesAcrossMarkets(t *testing.T) {
+	tr := newTestTracker(t)
+
+	tr.RecordFill(1, "ETH-USD", true, 1, 100, 1)
+	tr.RecordFill(1, "ETH-USD", false, 1, 150, 0)
+	tr.RecordFill(1, "BTC-USD", true, 1, 1000, 2)
+
+	pnl := tr.PnL(1)
+	if !almostEqual(pnl.RealizedPnL, 50) {
+		t.Errorf("RealizedPnL = %v, want 50", pnl.RealizedPnL)
+	}
+	if !almostEqual(pnl.FeesPaid, 3) {
+		t.Errorf("FeesPaid = %v, want 3", pnl.FeesPaid)
+	}
+	if len(pnl.Markets) != 2 {
+		t.Errorf("len(Markets) = %d, want 2", len(pnl.Markets))
+	}
+
+	btc := pnl.Markets["BTC-USD"]
+	if !almostEqual(btc.UnrealizedPnL, 0) {
+		t.Errorf("BTC-USD UnrealizedPnL = %v, want 0 at cost", btc.UnrealizedPnL)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	md "github.com/echenim/barter/internal/models"
 )
 
 type Bid struct {
-	ID        int64
-	UserID    int64
-	Size      float64
-	Bid       bool
-	Limit     *Limit
-	Timestamp int64
+	ID          int64
+	UserID      int64
+	Size        float64
+	Bid         bool
+	Limit       *Limit
+	Timestamp   int64
+	TimeInForce md.TimeInForce
 }
 
 type Bids []*Bid
@@ -28,15 +31,21 @@ func (o Bids) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
 func (o Bids) Less(i, j int) bool { return o[i].Timestamp < o[j].Timestamp }
 
 // NewBid creates and returns a new Bid instance.
-// It initializes a Bid with the provided bid status, size, and userID,
-// assigns a random ID, and sets the current time as the Timestamp.
-func NewBid(bid bool, size float64, userID int64) *Bid {
+// It initializes a Bid with the provided bid status, size, userID, and
+// time-in-force, assigns a random ID, and sets the current time as the
+// Timestamp. An empty TimeInForce defaults to GTC.
+func NewBid(bid bool, size float64, userID int64, tif md.TimeInForce) *Bid {
+	if tif == "" {
+		tif = md.GTC
+	}
+
 	return &Bid{
-		UserID:    userID,
-		ID:        int64(rand.Intn(10000000)),
-		Size:      size,
-		Bid:       bid,
-		Timestamp: time.Now().UnixNano(),
+		UserID:      userID,
+		ID:          int64(rand.Intn(10000000)),
+		Size:        size,
+		Bid:         bid,
+		Timestamp:   time.Now().UnixNano(),
+		TimeInForce: tif,
 	}
 }
 
@@ -1,14 +1,25 @@
 package bidder
 
 import (
+	"errors"
 	"fmt"
-	"sort"
 	"sync"
 	"time"
 
+	md "github.com/echenim/barter/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrBookBusy indicates PlaceBatch could not acquire the book's lock within
+// lockTimeout, e.g. because another batch is in flight. It is safe to retry.
+var ErrBookBusy = errors.New("book is busy, try again")
+
+// IsTransient reports whether err is safe to retry, as opposed to a
+// permanent rejection (e.g. a FOK order that could not be filled).
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrBookBusy)
+}
+
 // Note
 // here buy and sell orders are matched in real-time, and efficient,
 // thread-safe operations are crucial. The use of mutexes and careful structuring of
@@ -16,8 +27,8 @@ import (
 // concurrent environment.
 
 type BookBid struct {
-	asks []*Limit
-	bids []*Limit
+	asks *priceLevels
+	bids *priceLevels
 
 	Trades []*Trade
 
@@ -25,34 +36,55 @@ type BookBid struct {
 	AskLimits map[float64]*Limit
 	BidLimits map[float64]*Limit
 	Orders    map[int64]*Bid
+
+	// seq is the sequence number stamped on the most recently published Event.
+	seq uint64
+	// subscribers holds the live event channels handed out by Subscribe.
+	subscribers map[chan Event]struct{}
 }
 
 // NewBookBid creates and returns a new instance of BookBid.
-// This function initializes slices for asks, bids, and trades,
-// and creates maps for AskLimits, BidLimits, and Orders.
+// This function initializes the ask and bid price-level indexes and the
+// trades slice, and creates maps for AskLimits, BidLimits, and Orders.
 func NewBookBid() *BookBid {
 	return &BookBid{
-		asks:      []*Limit{},
-		bids:      []*Limit{},
+		asks:      newPriceLevels(true),
+		bids:      newPriceLevels(false),
 		Trades:    []*Trade{},
 		AskLimits: make(map[float64]*Limit),
 		BidLimits: make(map[float64]*Limit),
 		Orders:    make(map[int64]*Bid),
+
+		subscribers: make(map[chan Event]struct{}),
 	}
 }
 
 // PlaceMarketOrder places a market bid into the book.
 // It takes a Bid object as a parameter and returns a slice of Match objects.
 // This method locks the BookBid for concurrent access, calculates matches for the given bid,
-// and records the trades. It panics if there is insufficient volume for the bid.
-func (ob *BookBid) PlaceMarketOrder(o *Bid) []Match {
+// and records the trades. PostOnly is not valid for market orders and is rejected with an error.
+// FOK is rejected with an error rather than partially filled when the book can't cover it in
+// full; GTC/IOC market orders still panic on insufficient volume, matching prior behavior.
+func (ob *BookBid) PlaceMarketOrder(o *Bid) ([]Match, error) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
+	return ob.placeMarketOrderLocked(o)
+}
+
+// placeMarketOrderLocked is PlaceMarketOrder's implementation. Callers must hold ob.mu.
+func (ob *BookBid) placeMarketOrderLocked(o *Bid) ([]Match, error) {
+	if o.TimeInForce == md.PostOnly {
+		return nil, fmt.Errorf("post-only is not valid for market orders")
+	}
+
 	matches := []Match{}
 
 	if o.Bid {
 		if o.Size > ob.AskTotalVolume() {
+			if o.TimeInForce == md.FOK {
+				return nil, fmt.Errorf("fill-or-kill market order could not be filled in full [book: %.2f] [order: %.2f]", ob.AskTotalVolume(), o.Size)
+			}
 			panic(fmt.Errorf("not enough volume [size: %.2f] for market order [size: %.2f]", ob.AskTotalVolume(), o.Size))
 		}
 
@@ -66,6 +98,9 @@ func (ob *BookBid) PlaceMarketOrder(o *Bid) []Match {
 		}
 	} else {
 		if o.Size > ob.BidTotalVolume() {
+			if o.TimeInForce == md.FOK {
+				return nil, fmt.Errorf("fill-or-kill market order could not be filled in full [book: %.2f] [order: %.2f]", ob.BidTotalVolume(), o.Size)
+			}
 			panic(fmt.Errorf("not enough volume [size: %.2f] for market order [size: %.2f]", ob.BidTotalVolume(), o.Size))
 		}
 
@@ -79,32 +114,149 @@ func (ob *BookBid) PlaceMarketOrder(o *Bid) []Match {
 		}
 	}
 
-	for _, match := range matches {
-		trade := &Trade{
-			Price:     match.Price,
-			Size:      match.SizeFilled,
-			Timestamp: time.Now().UnixNano(),
-			Bid:       o.Bid,
-		}
-		ob.Trades = append(ob.Trades, trade)
-	}
+	ob.recordTrades(matches, o.Bid)
 
 	logrus.WithFields(logrus.Fields{
 		"currentPrice": ob.Trades[len(ob.Trades)-1].Price,
 	}).Info()
 
+	return matches, nil
+}
+
+// crosses reports whether an order of the given side at the given price would
+// immediately match against the resting book.
+func (ob *BookBid) crosses(bid bool, price float64) bool {
+	if bid {
+		asks := ob.Asks()
+		return len(asks) > 0 && price >= asks[0].Price
+	}
+
+	bids := ob.Bids()
+	return len(bids) > 0 && price <= bids[0].Price
+}
+
+// fillableAt sums the volume available to match against up to and including price,
+// without mutating the book. It is used to decide whether a FOK order can be filled.
+func (ob *BookBid) fillableAt(bid bool, price float64) float64 {
+	total := 0.0
+
+	if bid {
+		for _, limit := range ob.Asks() {
+			if limit.Price > price {
+				break
+			}
+			total += limit.TotalVolume
+		}
+	} else {
+		for _, limit := range ob.Bids() {
+			if limit.Price < price {
+				break
+			}
+			total += limit.TotalVolume
+		}
+	}
+
+	return total
+}
+
+// matchAt fills o against the resting book up to and including price, stopping
+// once o is filled or no further limit qualifies. Callers must hold ob.mu.
+func (ob *BookBid) matchAt(bid bool, price float64, o *Bid) []Match {
+	matches := []Match{}
+
+	if bid {
+		for _, limit := range ob.Asks() {
+			if o.IsFilled() || limit.Price > price {
+				break
+			}
+
+			matches = append(matches, limit.Fill(o)...)
+			if len(limit.Orders) == 0 {
+				ob.clearLimit(false, limit)
+			}
+		}
+	} else {
+		for _, limit := range ob.Bids() {
+			if o.IsFilled() || limit.Price < price {
+				break
+			}
+
+			matches = append(matches, limit.Fill(o)...)
+			if len(limit.Orders) == 0 {
+				ob.clearLimit(true, limit)
+			}
+		}
+	}
+
 	return matches
 }
 
-// PlaceLimitOrder places a limit order in the book.
-// It locks the current state, checks or creates the necessary limit,
-// logs the new order information, and adds the order to the limit and book.
-func (ob *BookBid) PlaceLimitOrder(price float64, o *Bid) {
-	var limit *Limit
+// recordTrades appends a Trade for each match to the book's trade tape and
+// publishes a TradeExecuted event for each. Callers must hold ob.mu.
+func (ob *BookBid) recordTrades(matches []Match, bid bool) {
+	for _, match := range matches {
+		now := time.Now().UnixNano()
+
+		ob.Trades = append(ob.Trades, &Trade{
+			Price:     match.Price,
+			Size:      match.SizeFilled,
+			Timestamp: now,
+			Bid:       bid,
+		})
+
+		ob.publish(Event{
+			Type:       EventTradeExecuted,
+			Bid:        bid,
+			Price:      match.Price,
+			Size:       match.SizeFilled,
+			Timestamp:  now,
+			BidOrderID: match.Bid.ID,
+			AskOrderID: match.Ask.ID,
+			BidUserID:  match.Bid.UserID,
+			AskUserID:  match.Ask.UserID,
+		})
+	}
+}
 
+// PlaceLimitOrder places a limit order in the book, honoring the order's TimeInForce:
+// GTC matches what it can at or better than price and rests the remainder; IOC matches
+// what it can and cancels any remainder instead of resting it; FOK only executes if the
+// full size can be matched at or better than price, rejecting the order entirely
+// otherwise; PostOnly rejects the order outright if it would immediately cross the book.
+func (ob *BookBid) PlaceLimitOrder(price float64, o *Bid) ([]Match, error) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
 
+	return ob.placeLimitOrderLocked(price, o)
+}
+
+// placeLimitOrderLocked is PlaceLimitOrder's implementation. Callers must hold ob.mu.
+func (ob *BookBid) placeLimitOrderLocked(price float64, o *Bid) ([]Match, error) {
+	if o.TimeInForce == md.PostOnly && ob.crosses(o.Bid, price) {
+		return nil, fmt.Errorf("post-only order would cross the book at price %.2f", price)
+	}
+
+	if o.TimeInForce == md.FOK && ob.fillableAt(o.Bid, price) < o.Size {
+		return nil, fmt.Errorf("fill-or-kill order could not be filled in full at price %.2f", price)
+	}
+
+	matches := ob.matchAt(o.Bid, price, o)
+	ob.recordTrades(matches, o.Bid)
+
+	if o.IsFilled() || o.TimeInForce == md.IOC || o.TimeInForce == md.FOK {
+		return matches, nil
+	}
+
+	ob.restLimitOrder(price, o)
+
+	return matches, nil
+}
+
+// restLimitOrder adds the (possibly partially filled) remainder of o to the book
+// at price, checking or creating the necessary limit. Callers must hold ob.mu.
+func (ob *BookBid) restLimitOrder(price float64, o *Bid) {
+	var limit *Limit
+
 	if o.Bid {
 		limit = ob.BidLimits[price]
 	} else {
@@ -115,10 +267,10 @@ func (ob *BookBid) PlaceLimitOrder(price float64, o *Bid) {
 		limit = NewLimit(price)
 
 		if o.Bid {
-			ob.bids = append(ob.bids, limit)
+			ob.bids.Put(limit)
 			ob.BidLimits[price] = limit
 		} else {
-			ob.asks = append(ob.asks, limit)
+			ob.asks.Put(limit)
 			ob.AskLimits[price] = limit
 		}
 	}
@@ -132,79 +284,246 @@ func (ob *BookBid) PlaceLimitOrder(price float64, o *Bid) {
 
 	ob.Orders[o.ID] = o
 	limit.AddOrder(o)
+
+	ob.publish(Event{
+		Type:      EventOrderAdded,
+		Bid:       o.Bid,
+		Price:     price,
+		Size:      o.Size,
+		OrderID:   o.ID,
+		Timestamp: o.Timestamp,
+	})
 }
 
 // clearLimit removes a limit from the book.
 // It is used when all orders at a limit have been fulfilled.
-// The function updates the limits map and the bids or asks slice depending on the type of limit.
+// The function updates the limits map and the bids or asks price-level index depending on the type of limit.
+// Callers must hold ob.mu.
 func (ob *BookBid) clearLimit(bid bool, l *Limit) {
 	if bid {
 		delete(ob.BidLimits, l.Price)
-		for i := 0; i < len(ob.bids); i++ {
-			if ob.bids[i] == l {
-				ob.bids[i] = ob.bids[len(ob.bids)-1]
-				ob.bids = ob.bids[:len(ob.bids)-1]
-			}
-		}
+		ob.bids.Remove(l.Price)
 	} else {
 		delete(ob.AskLimits, l.Price)
-		for i := 0; i < len(ob.asks); i++ {
-			if ob.asks[i] == l {
-				ob.asks[i] = ob.asks[len(ob.asks)-1]
-				ob.asks = ob.asks[:len(ob.asks)-1]
-			}
-		}
+		ob.asks.Remove(l.Price)
 	}
 
 	fmt.Printf("clearing limit price level [%.2f]\n", l.Price)
+
+	ob.publish(Event{
+		Type:      EventLimitCleared,
+		Bid:       bid,
+		Price:     l.Price,
+		Timestamp: time.Now().UnixNano(),
+	})
 }
 
 // CancelOrder handles the cancellation of an order.
 // It removes the order from its limit and the book, and clears the limit if it becomes empty.
 func (ob *BookBid) CancelOrder(o *Bid) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.cancelOrderLocked(o)
+}
+
+// CancelOrderByID looks up and cancels the order with id atomically under a
+// single lock, so a caller never needs to read ob.Orders itself — it's a
+// plain map mutated by every matching/resting/cancel path. It reports
+// whether an order with id was resting on the book.
+func (ob *BookBid) CancelOrderByID(id int64) (*Bid, bool) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	// A filled order stays in ob.Orders but has been removed from its limit.
+	o, ok := ob.Orders[id]
+	if !ok || o.Limit == nil {
+		return nil, false
+	}
+
+	ob.cancelOrderLocked(o)
+
+	return o, true
+}
+
+// cancelOrderLocked is CancelOrder's implementation. Callers must hold ob.mu.
+func (ob *BookBid) cancelOrderLocked(o *Bid) {
 	limit := o.Limit
 	limit.DeleteOrder(o)
 	delete(ob.Orders, o.ID)
 
+	ob.publish(Event{
+		Type:      EventOrderCanceled,
+		Bid:       o.Bid,
+		Price:     limit.Price,
+		Size:      o.Size,
+		OrderID:   o.ID,
+		Timestamp: time.Now().UnixNano(),
+	})
+
 	if len(limit.Orders) == 0 {
 		ob.clearLimit(o.Bid, limit)
 	}
 }
 
 // BidTotalVolume calculates the total volume of all bid orders.
-// It iterates over all bids and sums up their total volumes.
 func (ob *BookBid) BidTotalVolume() float64 {
-	totalVolume := 0.0
+	return ob.bids.TotalVolume()
+}
+
+// AskTotalVolume calculates the total volume of all ask orders.
+func (ob *BookBid) AskTotalVolume() float64 {
+	return ob.asks.TotalVolume()
+}
+
+// lockTimeout bounds how long PlaceBatch waits to acquire ob.mu before giving
+// up and returning ErrBookBusy, so a caller can retry instead of blocking
+// indefinitely behind another batch.
+const lockTimeout = 50 * time.Millisecond
+
+// BatchItem is a single order to place as part of a batch submitted to PlaceBatch.
+type BatchItem struct {
+	UserID        int64
+	Bid           bool
+	Size          float64
+	Price         float64
+	IsMarketOrder bool
+	TimeInForce   md.TimeInForce
+}
+
+// BatchResult is the outcome of placing a single BatchItem. Err is set on
+// failure; otherwise Order and Matches describe what was placed.
+type BatchResult struct {
+	Order   *Bid
+	Matches []Match
+	Err     error
+}
 
-	for i := 0; i < len(ob.bids); i++ {
-		totalVolume += ob.bids[i].TotalVolume
+// PlaceBatch places every item in items against ob as a single unit of work,
+// holding ob.mu for the whole batch so no other request can interleave with
+// it. It returns ErrBookBusy without placing anything if the lock can't be
+// acquired within lockTimeout.
+//
+// With atomic set, every item is first validated against the book's state at
+// the start of the batch; if any would fail, none of them are placed and
+// every result carries the same rejection error. Note this validates against
+// the pre-batch book state, not against the effects of earlier items in the
+// same batch — an item can still fail during execution if an earlier item in
+// the batch consumed the liquidity it depended on.
+func (ob *BookBid) PlaceBatch(items []BatchItem, atomic bool) ([]BatchResult, error) {
+	if !ob.tryLock(lockTimeout) {
+		return nil, ErrBookBusy
 	}
+	defer ob.mu.Unlock()
+
+	results := make([]BatchResult, len(items))
+
+	if atomic {
+		for i, item := range items {
+			if err := ob.validateItem(item); err != nil {
+				rejected := fmt.Errorf("batch rejected: item %d: %w", i, err)
+				for j := range results {
+					results[j] = BatchResult{Err: rejected}
+				}
+				return results, nil
+			}
+		}
+	}
+
+	for i, item := range items {
+		tif := item.TimeInForce
+		if tif == "" {
+			tif = md.GTC
+		}
+
+		order := NewBid(item.Bid, item.Size, item.UserID, tif)
 
-	return totalVolume
+		var (
+			matches []Match
+			err     error
+		)
+		if item.IsMarketOrder {
+			matches, err = ob.placeMarketOrderLocked(order)
+		} else {
+			matches, err = ob.placeLimitOrderLocked(item.Price, order)
+		}
+
+		if err != nil {
+			results[i] = BatchResult{Err: err}
+			continue
+		}
+
+		results[i] = BatchResult{Order: order, Matches: matches}
+	}
+
+	return results, nil
 }
 
-// AskTotalVolume calculates the total volume of all ask orders.
-// It iterates over all asks and sums up their total volumes.
-func (ob *BookBid) AskTotalVolume() float64 {
-	totalVolume := 0.0
+// validateItem reports whether item could be placed against ob's current
+// state without mutating anything. Used to pre-flight an atomic batch.
+func (ob *BookBid) validateItem(item BatchItem) error {
+	tif := item.TimeInForce
+	if tif == "" {
+		tif = md.GTC
+	}
 
-	for i := 0; i < len(ob.asks); i++ {
-		totalVolume += ob.asks[i].TotalVolume
+	if item.IsMarketOrder {
+		if tif == md.PostOnly {
+			return fmt.Errorf("post-only is not valid for market orders")
+		}
+		if tif != md.FOK {
+			return nil
+		}
+		if item.Bid && item.Size > ob.AskTotalVolume() {
+			return fmt.Errorf("fill-or-kill market order could not be filled in full")
+		}
+		if !item.Bid && item.Size > ob.BidTotalVolume() {
+			return fmt.Errorf("fill-or-kill market order could not be filled in full")
+		}
+		return nil
 	}
 
-	return totalVolume
+	if tif == md.PostOnly && ob.crosses(item.Bid, item.Price) {
+		return fmt.Errorf("post-only order would cross the book at price %.2f", item.Price)
+	}
+	if tif == md.FOK && ob.fillableAt(item.Bid, item.Price) < item.Size {
+		return fmt.Errorf("fill-or-kill order could not be filled in full at price %.2f", item.Price)
+	}
+
+	return nil
 }
 
-// Asks returns a sorted slice of all ask limits.
-// The limits are sorted based on the criteria defined in ByBestAsk.
+// tryLock attempts to acquire ob.mu, giving up after timeout instead of
+// blocking forever.
+func (ob *BookBid) tryLock(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if ob.mu.TryLock() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Asks returns every ask limit in ascending price order (best ask first).
 func (ob *BookBid) Asks() []*Limit {
-	sort.Sort(ByBestAsk{ob.asks})
-	return ob.asks
+	return ob.asks.Slice()
 }
 
-// Bids returns a sorted slice of all bid limits.
-// The limits are sorted based on the criteria defined in ByBestBid.
+// Bids returns every bid limit in descending price order (best bid first).
 func (ob *BookBid) Bids() []*Limit {
-	sort.Sort(ByBestBid{ob.bids})
-	return ob.bids
+	return ob.bids.Slice()
+}
+
+// BestAsk returns the lowest resting ask limit, if any.
+func (ob *BookBid) BestAsk() (*Limit, bool) {
+	return ob.asks.Best()
+}
+
+// BestBid returns the highest resting bid limit, if any.
+func (ob *BookBid) BestBid() (*Limit, bool) {
+	return ob.bids.Best()
 }
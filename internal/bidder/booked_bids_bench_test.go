@@ -0,0 +1,69 @@
+package bidder
+
+import (
+	"testing"
+
+	md "github.com/echenim/barter/internal/models"
+)
+
+// seedBook populates a fresh BookBid with levels resting price levels on each
+// side (bids below asks, never crossing) and ordersPerLevel orders resting at
+// each level, giving PlaceLimitOrder and PlaceMarketOrder a realistic depth
+// of book to run against.
+func seedBook(b *testing.B, levels, ordersPerLevel int) *BookBid {
+	b.Helper()
+
+	ob := NewBookBid()
+
+	for i := 0; i < levels; i++ {
+		bidPrice := float64(10_000 - i)
+		askPrice := float64(10_001 + i)
+
+		for j := 0; j < ordersPerLevel; j++ {
+			if _, err := ob.PlaceLimitOrder(bidPrice, NewBid(true, 1, int64(j), md.GTC)); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ob.PlaceLimitOrder(askPrice, NewBid(false, 1, int64(j), md.GTC)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	return ob
+}
+
+// BenchmarkPlaceLimitOrder_DeepBook rests non-crossing limit orders against a
+// book with 10k price levels (100k resting orders total): the regime in which
+// the old slice-backed Asks()/Bids() paid an O(n log n) sort and clearLimit
+// an O(n) scan on every call.
+func BenchmarkPlaceLimitOrder_DeepBook(b *testing.B) {
+	ob := seedBook(b, 5_000, 10)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		price := float64(9_000 - i%1_000)
+		if _, err := ob.PlaceLimitOrder(price, NewBid(true, 1, int64(i), md.GTC)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPlaceMarketOrder_DeepBook repeatedly crosses the best ask against
+// the same deep book, replenishing one unit of liquidity behind the book
+// after each fill so depth (and therefore the cost of a lookup) stays
+// representative for the whole run instead of draining to nothing.
+func BenchmarkPlaceMarketOrder_DeepBook(b *testing.B) {
+	ob := seedBook(b, 5_000, 10)
+	nextAskPrice := float64(10_001 + 5_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ob.PlaceMarketOrder(NewBid(true, 1, int64(i), md.GTC)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ob.PlaceLimitOrder(nextAskPrice, NewBid(false, 1, int64(i), md.GTC)); err != nil {
+			b.Fatal(err)
+		}
+		nextAskPrice++
+	}
+}
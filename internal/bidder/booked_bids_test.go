@@ -0,0 +1,188 @@
+package bidder
+
+import (
+	"testing"
+
+	md "github.com/echenim/barter/internal/models"
+)
+
+func TestPlaceLimitOrder_GTC_RestsTheUnfilledRemainder(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.GTC)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder: %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("len(matches) = %d, want 1", len(matches))
+	}
+	if bid.Size != 2 {
+		t.Errorf("bid.Size = %v, want 2 (1 filled, 2 resting)", bid.Size)
+	}
+	if bid.Limit == nil {
+		t.Error("bid.Limit = nil, want the remainder to rest on the book")
+	}
+}
+
+func TestPlaceLimitOrder_IOC_CancelsRatherThanRestsTheRemainder(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.IOC)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder: %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("len(matches) = %d, want 1", len(matches))
+	}
+	if bid.Size != 2 {
+		t.Errorf("bid.Size = %v, want 2 (the unmatched remainder)", bid.Size)
+	}
+	if bid.Limit != nil {
+		t.Error("bid.Limit != nil, want an IOC remainder to never rest")
+	}
+	if _, ok := ob.Orders[bid.ID]; ok {
+		t.Error("IOC remainder was added to ob.Orders, want it discarded")
+	}
+}
+
+func TestPlaceLimitOrder_IOC_FullyFilledLeavesNothingResting(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 5, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.IOC)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder: %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("len(matches) = %d, want 1", len(matches))
+	}
+	if !bid.IsFilled() {
+		t.Errorf("bid.Size = %v, want 0 (fully filled)", bid.Size)
+	}
+	if bid.Limit != nil {
+		t.Error("bid.Limit != nil, want a fully filled order to never rest")
+	}
+}
+
+func TestPlaceLimitOrder_FOK_RejectedWhenBookCannotCoverTheFullSize(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.FOK)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err == nil {
+		t.Fatal("PlaceLimitOrder with an unfillable FOK order = nil error, want a rejection")
+	}
+	if matches != nil {
+		t.Errorf("matches = %v, want nil on rejection", matches)
+	}
+	if bid.Size != 3 {
+		t.Errorf("bid.Size = %v, want 3 (untouched on rejection)", bid.Size)
+	}
+}
+
+func TestPlaceLimitOrder_FOK_FillsInFullWhenBookCanCoverIt(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 5, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.FOK)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("len(matches) = %d, want 1", len(matches))
+	}
+	if !bid.IsFilled() {
+		t.Errorf("bid.Size = %v, want 0 (fully filled)", bid.Size)
+	}
+	if bid.Limit != nil {
+		t.Error("bid.Limit != nil, want a fully filled FOK order to never rest")
+	}
+}
+
+func TestPlaceLimitOrder_PostOnly_RejectedWhenItWouldCrossTheBook(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 1, 2, md.PostOnly)
+	matches, err := ob.PlaceLimitOrder(100, bid)
+	if err == nil {
+		t.Fatal("PlaceLimitOrder with a crossing post-only order = nil error, want a rejection")
+	}
+	if matches != nil {
+		t.Errorf("matches = %v, want nil on rejection", matches)
+	}
+}
+
+func TestPlaceLimitOrder_PostOnly_RestsWhenItDoesNotCrossTheBook(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 1, 2, md.PostOnly)
+	matches, err := ob.PlaceLimitOrder(99, bid)
+	if err != nil {
+		t.Fatalf("PlaceLimitOrder: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("len(matches) = %d, want 0", len(matches))
+	}
+	if bid.Limit == nil {
+		t.Error("bid.Limit = nil, want a non-crossing post-only order to rest")
+	}
+}
+
+func TestPlaceMarketOrder_FOK_RejectedWhenBookCannotCoverTheFullSize(t *testing.T) {
+	ob := NewBookBid()
+
+	if _, err := ob.PlaceLimitOrder(100, NewBid(false, 1, 1, md.GTC)); err != nil {
+		t.Fatalf("seed ask: %v", err)
+	}
+
+	bid := NewBid(true, 3, 2, md.FOK)
+	matches, err := ob.PlaceMarketOrder(bid)
+	if err == nil {
+		t.Fatal("PlaceMarketOrder with an unfillable FOK order = nil error, want a rejection")
+	}
+	if matches != nil {
+		t.Errorf("matches = %v, want nil on rejection", matches)
+	}
+}
+
+func TestPlaceMarketOrder_PostOnly_Rejected(t *testing.T) {
+	ob := NewBookBid()
+
+	bid := NewBid(true, 1, 2, md.PostOnly)
+	if _, err := ob.PlaceMarketOrder(bid); err == nil {
+		t.Fatal("PlaceMarketOrder with a post-only order = nil error, want a rejection")
+	}
+}
@@ -0,0 +1,70 @@
+package bidder
+
+import (
+	rbt "github.com/emirpasic/gods/trees/redblacktree"
+	"github.com/emirpasic/gods/utils"
+)
+
+// priceLevels indexes Limits by price in a red-black tree, replacing the
+// sort-on-every-access slice this package used to use for the ask and bid
+// sides of the book. Insert, remove, and best-price lookups are all O(log n)
+// worst case, and in-order iteration (for GetBook and matching) needs no sort.
+type priceLevels struct {
+	tree *rbt.Tree
+}
+
+// newPriceLevels creates a priceLevels indexed by price. ascending orders the
+// book's best price first when true (used for asks, where the best price is
+// the lowest) or last-to-first when false (used for bids, where the best
+// price is the highest).
+func newPriceLevels(ascending bool) *priceLevels {
+	if ascending {
+		return &priceLevels{tree: rbt.NewWith(utils.Float64Comparator)}
+	}
+	return &priceLevels{tree: rbt.NewWith(descendingFloat64Comparator)}
+}
+
+// descendingFloat64Comparator orders float64 keys from highest to lowest, so
+// that the resulting tree's "first" (Left()) entry is the maximum.
+func descendingFloat64Comparator(a, b any) int {
+	return utils.Float64Comparator(b, a)
+}
+
+// Put indexes l by its price.
+func (p *priceLevels) Put(l *Limit) {
+	p.tree.Put(l.Price, l)
+}
+
+// Remove drops the Limit at price from the index.
+func (p *priceLevels) Remove(price float64) {
+	p.tree.Remove(price)
+}
+
+// Best returns the book's best Limit on this side (lowest price for asks,
+// highest for bids), if any.
+func (p *priceLevels) Best() (*Limit, bool) {
+	node := p.tree.Left()
+	if node == nil {
+		return nil, false
+	}
+	return node.Value.(*Limit), true
+}
+
+// Slice returns every indexed Limit in best-first order.
+func (p *priceLevels) Slice() []*Limit {
+	values := p.tree.Values()
+	limits := make([]*Limit, len(values))
+	for i, v := range values {
+		limits[i] = v.(*Limit)
+	}
+	return limits
+}
+
+// TotalVolume sums TotalVolume across every indexed Limit.
+func (p *priceLevels) TotalVolume() float64 {
+	total := 0.0
+	for _, v := range p.tree.Values() {
+		total += v.(*Limit).TotalVolume
+	}
+	return total
+}
@@ -0,0 +1,142 @@
+package bidder
+
+// EventType identifies the kind of book event published by a BookBid.
+type EventType string
+
+const (
+	EventOrderAdded    EventType = "ORDER_ADDED"
+	EventOrderCanceled EventType = "ORDER_CANCELED"
+	EventLimitCleared  EventType = "LIMIT_CLEARED"
+	EventTradeExecuted EventType = "TRADE_EXECUTED"
+)
+
+// Event is a single book mutation or trade, tagged with a monotonically
+// increasing sequence number so subscribers can detect gaps and re-snapshot.
+type Event struct {
+	Seq       uint64
+	Type      EventType
+	Bid       bool
+	Price     float64
+	Size      float64
+	OrderID   int64
+	Timestamp int64
+
+	// BidOrderID, AskOrderID, BidUserID, and AskUserID identify both sides of
+	// a TradeExecuted event; they are zero for every other event type.
+	BidOrderID int64
+	AskOrderID int64
+	BidUserID  int64
+	AskUserID  int64
+}
+
+// subscriberBufferSize bounds how many events a subscriber may lag behind
+// before it is dropped instead of blocking the matching loop.
+const subscriberBufferSize = 256
+
+// Subscribe registers a new subscriber and returns a channel of book events
+// along with an unsubscribe function. Call unsubscribe when done to release
+// the channel; it is also safe to let publish drop the subscriber itself.
+func (ob *BookBid) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	ob.mu.Lock()
+	if ob.subscribers == nil {
+		ob.subscribers = make(map[chan Event]struct{})
+	}
+	ob.subscribers[ch] = struct{}{}
+	ob.mu.Unlock()
+
+	unsubscribe := func() {
+		ob.mu.Lock()
+		ob.removeSubscriber(ch)
+		ob.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// LimitSnapshot is a point-in-time copy of a single price level's price,
+// total volume, and resting orders. Unlike a *Limit, it shares no state with
+// the live book, so it stays safe to read after the snapshot was taken even
+// while the matching engine keeps mutating that price level.
+type LimitSnapshot struct {
+	Price       float64
+	TotalVolume float64
+	Orders      []Bid
+}
+
+// SubscribeWithSnapshot atomically registers a new subscriber and captures
+// the book's current sequence number and resting asks/bids, all under a
+// single lock acquisition. Doing this in two steps — Subscribe() followed by
+// a separate read of Seq()/Asks()/Bids() — leaves a gap in which a trade can
+// publish an event that lands in the subscriber's channel *and* is already
+// reflected in the separately-read snapshot, breaking the invariant that
+// every delta's Seq is greater than the snapshot's. The asks/bids returned
+// are copies (see LimitSnapshot), not live *Limit pointers, so the caller
+// can read them after ob.mu is released without racing the matching engine.
+func (ob *BookBid) SubscribeWithSnapshot() (events <-chan Event, unsubscribe func(), seq uint64, asks, bids []LimitSnapshot) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	ob.mu.Lock()
+	if ob.subscribers == nil {
+		ob.subscribers = make(map[chan Event]struct{})
+	}
+	ob.subscribers[ch] = struct{}{}
+	seq = ob.seq
+	asks = snapshotLimits(ob.asks.Slice())
+	bids = snapshotLimits(ob.bids.Slice())
+	ob.mu.Unlock()
+
+	unsubscribe = func() {
+		ob.mu.Lock()
+		ob.removeSubscriber(ch)
+		ob.mu.Unlock()
+	}
+
+	return ch, unsubscribe, seq, asks, bids
+}
+
+// snapshotLimits copies every Limit in limits into a LimitSnapshot. Callers
+// must hold ob.mu.
+func snapshotLimits(limits []*Limit) []LimitSnapshot {
+	out := make([]LimitSnapshot, len(limits))
+	for i, l := range limits {
+		orders := make([]Bid, len(l.Orders))
+		for j, o := range l.Orders {
+			orders[j] = *o
+		}
+		out[i] = LimitSnapshot{Price: l.Price, TotalVolume: l.TotalVolume, Orders: orders}
+	}
+	return out
+}
+
+// Seq returns the current event sequence number for ob.
+func (ob *BookBid) Seq() uint64 {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	return ob.seq
+}
+
+// publish stamps evt with the next sequence number and delivers it to every
+// subscriber. A subscriber whose buffer is full is dropped rather than
+// allowed to block the matching loop. Callers must hold ob.mu.
+func (ob *BookBid) publish(evt Event) {
+	ob.seq++
+	evt.Seq = ob.seq
+
+	for ch := range ob.subscribers {
+		select {
+		case ch <- evt:
+		default:
+			ob.removeSubscriber(ch)
+		}
+	}
+}
+
+// removeSubscriber deletes and closes ch. Callers must hold ob.mu.
+func (ob *BookBid) removeSubscriber(ch chan Event) {
+	if _, ok := ob.subscribers[ch]; ok {
+		delete(ob.subscribers, ch)
+		close(ch)
+	}
+}
@@ -11,8 +11,10 @@ import (
 	"strconv"
 	"sync"
 
+	ac "github.com/echenim/barter/internal/accounting"
 	bd "github.com/echenim/barter/internal/bidder"
 	md "github.com/echenim/barter/internal/models"
+	"github.com/echenim/barter/internal/risk"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -33,29 +35,248 @@ type Exchange struct {
 	mu     sync.RWMutex
 	Users  map[int64]*md.User
 	// bid maps a user to his bids.
-	bids     map[int64][]*bd.Bid
+	bids       map[int64][]*bd.Bid
 	PrivateKey *ecdsa.PrivateKey
-	bidBk map[md.Market]*bd.BookBid
+	bidBk      map[md.Market]*bd.BookBid
+	// markets holds the registered configuration for every tradable market.
+	markets map[md.Market]*md.MarketConfig
+	// settlements holds the settlement backend bound to each registered market.
+	settlements map[md.Market]Settlement
+	// risk, when set, gates every order through pre-trade checks and the
+	// loss-based circuit breaker. A nil risk disables risk checks entirely.
+	risk *risk.Guard
+	// accounting, when set, tracks per-user position and PnL accounting from
+	// every market's trade stream. A nil accounting disables /pnl entirely.
+	accounting *ac.Tracker
 }
 
-// NewExchange creates a new Exchange instance with a specified private key and Ethereum client.
-// It initializes the bid book for the ETH market.
-func NewExchange(privateKey string, client *ethclient.Client) (*Exchange, error) {
-	bidbooks := make(map[md.Market]*bd.BookBid)
-	bidbooks[MarketETH] = bd.NewBookBid()
+// UseRiskGuard installs g as the exchange's risk guard, gating every order
+// through its pre-trade checks and recording fills against its breaker.
+// Passing nil disables risk checks.
+func (ex *Exchange) UseRiskGuard(g *risk.Guard) {
+	ex.risk = g
+}
+
+// UseAccounting installs t as the exchange's position tracker. Every market
+// already registered, and every market registered afterward, feeds its trade
+// stream into t so /pnl/:userID reflects fills as they happen.
+func (ex *Exchange) UseAccounting(t *ac.Tracker) {
+	ex.mu.Lock()
+	ex.accounting = t
+	ex.mu.Unlock()
+}
 
+// DefaultETHMarket returns the native-ETH market configuration the exchange
+// registered by default before multi-market support was added.
+func DefaultETHMarket() md.MarketConfig {
+	return md.MarketConfig{
+		Market:      MarketETH,
+		BaseAsset:   "ETH",
+		QuoteAsset:  "USD",
+		TickSize:    0.01,
+		LotSize:     0.0001,
+		MinNotional: 1,
+		Settlement:  md.SettlementNativeETH,
+	}
+}
+
+// NewExchange creates a new Exchange instance with a specified private key and Ethereum
+// client, registering a BookBid and settlement backend for every market in markets.
+func NewExchange(privateKey string, client *ethclient.Client, markets []md.MarketConfig) (*Exchange, error) {
 	pk, err := crypto.HexToECDSA(privateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Exchange{
-		Client:     client,
-		Users:      make(map[int64]*md.User),
-		bids:     make(map[int64][]*bd.Bid),
-		PrivateKey: pk,
-		bidBk: bidbooks,
-	}, nil
+	ex := &Exchange{
+		Client:      client,
+		Users:       make(map[int64]*md.User),
+		bids:        make(map[int64][]*bd.Bid),
+		PrivateKey:  pk,
+		bidBk:       make(map[md.Market]*bd.BookBid),
+		markets:     make(map[md.Market]*md.MarketConfig),
+		settlements: make(map[md.Market]Settlement),
+	}
+
+	for _, cfg := range markets {
+		if err := ex.registerMarket(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return ex, nil
+}
+
+// registerMarket adds a new market to the exchange, creating its order book and
+// binding its settlement backend. It is safe to call after startup, e.g. from
+// the /admin/markets endpoint.
+func (ex *Exchange) registerMarket(cfg md.MarketConfig) error {
+	settlement, err := newSettlement(ex.Client, cfg)
+	if err != nil {
+		return err
+	}
+
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	cfgCopy := cfg
+	ex.markets[cfg.Market] = &cfgCopy
+	ex.settlements[cfg.Market] = settlement
+	ob := bd.NewBookBid()
+	ex.bidBk[cfg.Market] = ob
+
+	go ex.accountTrades(cfg.Market, ob)
+
+	return nil
+}
+
+// accountTrades feeds every trade executed on ob into the exchange's
+// accounting tracker, crediting both the bid and ask side of the fill. It
+// runs for the lifetime of the market, so it tolerates UseAccounting being
+// called after the market is registered (the common startup order).
+func (ex *Exchange) accountTrades(market md.Market, ob *bd.BookBid) {
+	events, _ := ob.Subscribe()
+
+	// Subscribe hands back the same drop-on-full-buffer channel WS clients
+	// use, which is the right policy for an untrusted client that can't keep
+	// up. It is the wrong policy here: RecordFill does a synchronous file
+	// write on every fill, and if that (or just a burst of trades) ever backs
+	// the buffer up, publish would silently drop this subscriber and stop
+	// accounting for market forever with no log line. relayUnbounded drains
+	// events into an unbounded queue immediately, decoupling the slow
+	// RecordFill write from how fast this subscriber appears to consume.
+	queue := relayUnbounded(events)
+
+	for evt := range queue {
+		if evt.Type != bd.EventTradeExecuted {
+			continue
+		}
+
+		ex.mu.RLock()
+		tracker := ex.accounting
+		ex.mu.RUnlock()
+
+		if tracker == nil {
+			continue
+		}
+
+		tracker.RecordFill(evt.BidUserID, string(market), true, evt.Size, evt.Price, 0)
+		tracker.RecordFill(evt.AskUserID, string(market), false, evt.Size, evt.Price, 0)
+	}
+}
+
+// relayUnbounded drains in as fast as it's sent to, buffering in an
+// unbounded in-memory queue, and forwards to the returned channel at
+// whatever pace the consumer reads it. Use it to insulate a slow or bursty
+// consumer from a bounded, drop-on-full producer channel like
+// bidder.BookBid's event stream.
+func relayUnbounded(in <-chan bd.Event) <-chan bd.Event {
+	out := make(chan bd.Event)
+
+	go func() {
+		defer close(out)
+
+		var pending []bd.Event
+		for {
+			if len(pending) == 0 {
+				evt, ok := <-in
+				if !ok {
+					return
+				}
+				pending = append(pending, evt)
+				continue
+			}
+
+			select {
+			case evt, ok := <-in:
+				if !ok {
+					for _, evt := range pending {
+						out <- evt
+					}
+					return
+				}
+				pending = append(pending, evt)
+			case out <- pending[0]:
+				pending = pending[1:]
+			}
+		}
+	}()
+
+	return out
+}
+
+// RegisterMarket handles POST /admin/markets, registering a new market at runtime.
+func (ex *Exchange) RegisterMarket(c echo.Context) error {
+	var cfg md.MarketConfig
+	if err := json.NewDecoder(c.Request().Body).Decode(&cfg); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	if cfg.Market == "" {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "market is required"})
+	}
+
+	if err := ex.registerMarket(cfg); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"market":     cfg.Market,
+		"settlement": cfg.Settlement,
+	}).Info("registered market")
+
+	return c.JSON(http.StatusOK, map[string]any{"msg": "market registered"})
+}
+
+// Book returns the order book registered for market, if any, so packages
+// outside handlers (e.g. the WebSocket stream server) can subscribe to it.
+func (ex *Exchange) Book(market md.Market) (*bd.BookBid, bool) {
+	ex.mu.RLock()
+	defer ex.mu.RUnlock()
+
+	ob, ok := ex.bidBk[market]
+	return ob, ok
+}
+
+// ResetRisk handles POST /admin/risk/reset, clearing a tripped breaker scope
+// ("exchange", "user:<id>", or "market:<symbol>") so trading can resume.
+func (ex *Exchange) ResetRisk(c echo.Context) error {
+	if ex.risk == nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "risk guard not configured"})
+	}
+
+	var body struct {
+		Scope string
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	if err := ex.risk.Reset(body.Scope); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"msg": "risk scope reset"})
+}
+
+// SetKillSwitch handles POST /admin/risk/kill-switch, engaging or disengaging
+// the exchange-wide kill switch so an operator can halt new orders without a
+// restart, independent of any per-user or per-market breaker.
+func (ex *Exchange) SetKillSwitch(c echo.Context) error {
+	if ex.risk == nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "risk guard not configured"})
+	}
+
+	var body struct {
+		Engaged bool
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	ex.risk.SetKillSwitch(body.Engaged)
+
+	return c.JSON(http.StatusOK, map[string]any{"msg": "kill switch updated", "engaged": body.Engaged})
 }
 
 type GetOrdersResponse struct {
@@ -76,7 +297,10 @@ func (ex *Exchange) RegisterUser(pk string, userId int64) {
 // GetTrades returns the trades for a specified market from the exchange.
 func (ex *Exchange) GetTrades(c echo.Context) error {
 	market := md.Market(c.Param("market"))
+
+	ex.mu.RLock()
 	ob, ok := ex.bidBk[market]
+	ex.mu.RUnlock()
 	if !ok {
 		return c.JSON(http.StatusBadRequest, md.APIError{Error: "bidBK not found"})
 	}
@@ -126,10 +350,28 @@ func (ex *Exchange) GetOrders(c echo.Context) error {
 	return c.JSON(http.StatusOK, ordersResp)
 }
 
+// GetPnL handles GET /pnl/:userID, returning the user's realized and
+// unrealized PnL, fees paid, and per-market breakdown.
+func (ex *Exchange) GetPnL(c echo.Context) error {
+	if ex.accounting == nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "accounting not configured"})
+	}
+
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, ex.accounting.PnL(int64(userID)))
+}
+
 // GetBook provides the order book for a specified market, including total bid and ask volumes.
 func (ex *Exchange) GetBook(c echo.Context) error {
 	market := md.Market(c.Param("market"))
+
+	ex.mu.RLock()
 	ob, ok := ex.bidBk[market]
+	ex.mu.RUnlock()
 	if !ok {
 		return c.JSON(http.StatusBadRequest, map[string]any{"msg": "market not found"})
 	}
@@ -178,17 +420,18 @@ type PriceResponse struct {
 
 // GetBestBid finds the best (highest) bid for a specified market.
 func (ex *Exchange) GetBestBid(c echo.Context) error {
-	var (
-		market = md.Market(c.Param("market"))
-		ob     = ex.bidBk[market]
-		order  = md.Order{}
-	)
+	market := md.Market(c.Param("market"))
+	order := md.Order{}
+
+	ex.mu.RLock()
+	ob := ex.bidBk[market]
+	ex.mu.RUnlock()
 
-	if len(ob.Bids()) == 0 {
+	bestLimit, ok := ob.BestBid()
+	if !ok {
 		return c.JSON(http.StatusOK, order)
 	}
 
-	bestLimit := ob.Bids()[0]
 	bestOrder := bestLimit.Orders[0]
 
 	order.Price = bestLimit.Price
@@ -199,17 +442,18 @@ func (ex *Exchange) GetBestBid(c echo.Context) error {
 
 // GetBestAsk finds the best (lowest) ask for a specified market.
 func (ex *Exchange) GetBestAsk(c echo.Context) error {
-	var (
-		market = md.Market(c.Param("market"))
-		ob     = ex.bidBk[market]
-		order  = md.Order{}
-	)
+	market := md.Market(c.Param("market"))
+	order := md.Order{}
+
+	ex.mu.RLock()
+	ob := ex.bidBk[market]
+	ex.mu.RUnlock()
 
-	if len(ob.Asks()) == 0 {
+	bestLimit, ok := ob.BestAsk()
+	if !ok {
 		return c.JSON(http.StatusOK, order)
 	}
 
-	bestLimit := ob.Asks()[0]
 	bestOrder := bestLimit.Orders[0]
 
 	order.Price = bestLimit.Price
@@ -218,14 +462,27 @@ func (ex *Exchange) GetBestAsk(c echo.Context) error {
 	return c.JSON(http.StatusOK, order)
 }
 
-// CancelOrder cancels a specific bid by its ID in the ETH market.
+// CancelBid cancels a specific bid by its ID in the given market.
 func (ex *Exchange) CancelBid(c echo.Context) error {
+	market := md.Market(c.Param("market"))
 	idStr := c.Param("id")
 	id, _ := strconv.Atoi(idStr)
 
-	ob := ex.bidBk[MarketETH]
-	bid := ob.Orders[int64(id)]
-	ob.CancelOrder(bid)
+	ex.mu.RLock()
+	ob, ok := ex.bidBk[market]
+	ex.mu.RUnlock()
+	if !ok {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "market not found"})
+	}
+
+	bid, ok := ob.CancelOrderByID(int64(id))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "order not found"})
+	}
+
+	if ex.risk != nil {
+		ex.risk.OrderClosed(bid.ID)
+	}
 
 	log.Println("order canceled id => ", id)
 
@@ -233,9 +490,15 @@ func (ex *Exchange) CancelBid(c echo.Context) error {
 }
 
 // placeMarketOrder processes a market bid, matching it with existing bid in the book.
-func (ex *Exchange) placeMarketOrder(market md.Market, bid *bd.Bid) ([]bd.Match, []*md.MatchedBid) {
+func (ex *Exchange) placeMarketOrder(market md.Market, bid *bd.Bid) ([]bd.Match, []*md.MatchedBid, error) {
+	ex.mu.RLock()
 	ob := ex.bidBk[market]
-	matches := ob.PlaceMarketOrder(bid)
+	ex.mu.RUnlock()
+
+	matches, err := ob.PlaceMarketOrder(bid)
+	if err != nil {
+		return nil, nil, err
+	}
 	matchedBid := make([]*md.MatchedBid, len(matches))
 
 	isBid := false
@@ -277,9 +540,10 @@ func (ex *Exchange) placeMarketOrder(market md.Market, bid *bd.Bid) ([]bd.Match,
 	ex.mu.Lock()
 	for userID, bidBK := range ex.bids {
 		for i := 0; i < len(bidBK); i++ {
-			// If the bid is not filled we place it in the map copy.
-			// this means that size of the bids = 0
-			if !bidBK[i].IsFilled() {
+			// Keep only bids that are still resting on the book. A filled bid
+			// has Size == 0; a canceled one (including an IOC/FOK remainder
+			// that never rested) has Limit == nil and is never coming back.
+			if bidBK[i].Limit != nil && !bidBK[i].IsFilled() {
 				newOrderMap[userID] = append(newOrderMap[userID], bidBK[i])
 			}
 		}
@@ -287,20 +551,36 @@ func (ex *Exchange) placeMarketOrder(market md.Market, bid *bd.Bid) ([]bd.Match,
 	ex.bids = newOrderMap
 	ex.mu.Unlock()
 
-	return matches, matchedBid
+	return matches, matchedBid, nil
 }
 
-// placeLimitOrder adds a limit bid to the bid book for a specific market.
-func (ex *Exchange) placeLimitOrder(market md.Market, price float64, bid *bd.Bid) error {
+// placeLimitOrder adds a limit bid to the bid book for a specific market,
+// returning any matches made immediately against the resting book.
+func (ex *Exchange) placeLimitOrder(market md.Market, price float64, bid *bd.Bid) ([]bd.Match, error) {
+	ex.mu.RLock()
 	ob := ex.bidBk[market]
-	ob.PlaceLimitOrder(price, bid)
+	ex.mu.RUnlock()
 
-	// keep track of the user bid
-	ex.mu.Lock()
-	ex.bids[bid.UserID] = append(ex.bids[bid.UserID], bid)
-	ex.mu.Unlock()
+	matches, err := ob.PlaceLimitOrder(price, bid)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	// Only track the bid if it's actually resting on the book. An IOC/FOK
+	// order that didn't fill in full never rests (bid.Limit stays nil) and
+	// its leftover Size is never zeroed either, so it would never satisfy
+	// IsFilled() and would leak in ex.bids forever if we appended it here.
+	if bid.Limit != nil {
+		ex.mu.Lock()
+		ex.bids[bid.UserID] = append(ex.bids[bid.UserID], bid)
+		ex.mu.Unlock()
+	}
+
+	if ex.risk != nil && bid.Limit != nil {
+		ex.risk.OrderAccepted(bid.ID, bid.UserID, bid.Size, price)
+	}
+
+	return matches, nil
 }
 
 type PlaceOrderResponse struct {
@@ -314,20 +594,65 @@ func (ex *Exchange) PlaceOrder(c echo.Context) error {
 		return err
 	}
 
+	if placeOrderData.TimeInForce == "" {
+		placeOrderData.TimeInForce = md.GTC
+	}
+
 	market := md.Market(placeOrderData.Market)
-	order := bd.NewBid(placeOrderData.Bid, placeOrderData.Size, placeOrderData.UserID)
+	ex.mu.RLock()
+	ob, ok := ex.bidBk[market]
+	cfg := ex.markets[market]
+	ex.mu.RUnlock()
+	if !ok {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "market not found"})
+	}
+
+	isMarketOrder := placeOrderData.Type == MarketOrder
+	if cfg != nil {
+		if err := cfg.ValidateOrder(placeOrderData.Price, placeOrderData.Size, isMarketOrder); err != nil {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+		}
+	}
+
+	if ex.risk != nil {
+		availableLiquidity := 0.0
+		if isMarketOrder {
+			if placeOrderData.Bid {
+				availableLiquidity = ob.AskTotalVolume()
+			} else {
+				availableLiquidity = ob.BidTotalVolume()
+			}
+		}
+
+		if err := ex.risk.PreTradeCheck(placeOrderData.UserID, string(market), placeOrderData.Size, placeOrderData.Price, isMarketOrder, availableLiquidity); err != nil {
+			status := http.StatusBadRequest
+			if _, tripped := err.(*risk.TrippedError); tripped {
+				status = http.StatusLocked
+			}
+			return c.JSON(status, md.APIError{Error: err.Error()})
+		}
+	}
+
+	order := bd.NewBid(placeOrderData.Bid, placeOrderData.Size, placeOrderData.UserID, placeOrderData.TimeInForce)
 
 	// Limit bids
 	if placeOrderData.Type == LimitOrder {
-		if err := ex.placeLimitOrder(market, placeOrderData.Price, order); err != nil {
+		matches, err := ex.placeLimitOrder(market, placeOrderData.Price, order)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+		}
+		if err := ex.matches(market, matches); err != nil {
 			return err
 		}
 	}
 
 	// market bid
 	if placeOrderData.Type == MarketOrder {
-		matches, _ := ex.placeMarketOrder(market, order)
-		if err := ex.matches(matches); err != nil {
+		matches, _, err := ex.placeMarketOrder(market, order)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+		}
+		if err := ex.matches(market, matches); err != nil {
 			return err
 		}
 	}
@@ -339,8 +664,18 @@ func (ex *Exchange) PlaceOrder(c echo.Context) error {
 	return c.JSON(200, resp)
 }
 
-// matches updates user balances based on the results of executed trades.
-func (ex *Exchange) matches(matches []bd.Match) error {
+// matches settles the results of executed trades on market using that market's
+// registered settlement backend.
+func (ex *Exchange) matches(market md.Market, matches []bd.Match) error {
+	ex.mu.RLock()
+	settlement, ok := ex.settlements[market]
+	ex.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("market not found: %s", market)
+	}
+
+	ctx := context.Background()
+
 	for _, match := range matches {
 		fromUser, ok := ex.Users[match.Ask.UserID]
 		if !ok {
@@ -361,15 +696,28 @@ func (ex *Exchange) matches(matches []bd.Match) error {
 		// }
 
 		amount := big.NewInt(int64(match.SizeFilled))
-		transferETH(ex.Client, fromUser.PrivateKey, toAddresss, amount)
+		if err := settlement.Transfer(ctx, fromUser.PrivateKey, toAddresss, amount); err != nil {
+			return err
+		}
+
+		if ex.risk != nil {
+			ex.risk.RecordFill(match.Bid.UserID, string(market), true, match.SizeFilled, match.Price)
+			ex.risk.RecordFill(match.Ask.UserID, string(market), false, match.SizeFilled, match.Price)
+
+			if match.Bid.IsFilled() {
+				ex.risk.OrderClosed(match.Bid.ID)
+			}
+			if match.Ask.IsFilled() {
+				ex.risk.OrderClosed(match.Ask.ID)
+			}
+		}
 	}
 
 	return nil
 }
 
 // transferETH handles the Ethereum transaction for transferring ETH from one user to another.
-func transferETH(client *ethclient.Client, fromPrivKey *ecdsa.PrivateKey, to common.Address, amount *big.Int) error {
-	ctx := context.Background()
+func transferETH(ctx context.Context, client *ethclient.Client, fromPrivKey *ecdsa.PrivateKey, to common.Address, amount *big.Int) error {
 	publicKey := fromPrivKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
 	if !ok {
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	bd "github.com/echenim/barter/internal/bidder"
+	md "github.com/echenim/barter/internal/models"
+	"github.com/labstack/echo/v4"
+)
+
+// BatchPlaceOptions controls how BatchPlace executes a batch of orders.
+type BatchPlaceOptions struct {
+	// Atomic, when true, rejects the entire batch if any one item fails
+	// validation against the book's state at the start of the batch. See
+	// bidder.BookBid.PlaceBatch for the exact semantics.
+	Atomic bool
+}
+
+// BatchPlaceResult is the outcome of placing a single order within a batch.
+type BatchPlaceResult struct {
+	OrderID int64
+	Error   string `json:",omitempty"`
+}
+
+// BatchPlace places every request in reqs against market as a single unit of
+// work, holding the market's book lock for the whole batch so no other
+// request can interleave with it.
+func (ex *Exchange) BatchPlace(ctx context.Context, market md.Market, reqs []md.PlaceOrderRequest, opts BatchPlaceOptions) ([]BatchPlaceResult, error) {
+	ex.mu.RLock()
+	ob, ok := ex.bidBk[market]
+	cfg := ex.markets[market]
+	ex.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("market not found: %s", market)
+	}
+
+	results := make([]BatchPlaceResult, len(reqs))
+
+	// itemIdx maps a position in items (and batchResults below) back to its
+	// original position in reqs, since a request that fails validation
+	// never becomes an item.
+	items := make([]bd.BatchItem, 0, len(reqs))
+	itemIdx := make([]int, 0, len(reqs))
+
+	for i, req := range reqs {
+		isMarketOrder := req.Type == MarketOrder
+
+		if cfg != nil {
+			if err := cfg.ValidateOrder(req.Price, req.Size, isMarketOrder); err != nil {
+				if opts.Atomic {
+					return nil, err
+				}
+				results[i] = BatchPlaceResult{Error: err.Error()}
+				continue
+			}
+		}
+
+		if ex.risk != nil {
+			availableLiquidity := 0.0
+			if isMarketOrder {
+				if req.Bid {
+					availableLiquidity = ob.AskTotalVolume()
+				} else {
+					availableLiquidity = ob.BidTotalVolume()
+				}
+			}
+
+			if err := ex.risk.PreTradeCheck(req.UserID, string(market), req.Size, req.Price, isMarketOrder, availableLiquidity); err != nil {
+				if opts.Atomic {
+					return nil, err
+				}
+				results[i] = BatchPlaceResult{Error: err.Error()}
+				continue
+			}
+		}
+
+		items = append(items, bd.BatchItem{
+			UserID:        req.UserID,
+			Bid:           req.Bid,
+			Size:          req.Size,
+			Price:         req.Price,
+			IsMarketOrder: req.Type == MarketOrder,
+			TimeInForce:   req.TimeInForce,
+		})
+		itemIdx = append(itemIdx, i)
+	}
+
+	batchResults, err := ob.PlaceBatch(items, opts.Atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	ex.mu.Lock()
+	for i, br := range batchResults {
+		if br.Err != nil {
+			results[itemIdx[i]] = BatchPlaceResult{Error: br.Err.Error()}
+			continue
+		}
+
+		results[itemIdx[i]] = BatchPlaceResult{OrderID: br.Order.ID}
+		ex.bids[br.Order.UserID] = append(ex.bids[br.Order.UserID], br.Order)
+	}
+	ex.mu.Unlock()
+
+	for _, br := range batchResults {
+		if br.Err != nil {
+			continue
+		}
+
+		if ex.risk != nil && br.Order.Limit != nil {
+			ex.risk.OrderAccepted(br.Order.ID, br.Order.UserID, br.Order.Size, br.Order.Limit.Price)
+		}
+
+		if len(br.Matches) == 0 {
+			continue
+		}
+		if err := ex.matches(market, br.Matches); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// retryBackoff bounds the wait between BatchRetryPlace attempts.
+func retryBackoff(attempt int) time.Duration {
+	d := time.Duration(attempt+1) * 10 * time.Millisecond
+	if d > 200*time.Millisecond {
+		return 200 * time.Millisecond
+	}
+	return d
+}
+
+// BatchRetryPlace places reqs like BatchPlace, retrying the whole batch up to
+// maxRetries times with a short backoff when it fails with a transient error
+// (e.g. book lock contention under heavy load). Per-item rejections, such as
+// a FOK order that couldn't be filled, are permanent and are never retried.
+func (ex *Exchange) BatchRetryPlace(ctx context.Context, market md.Market, reqs []md.PlaceOrderRequest, opts BatchPlaceOptions, maxRetries int) ([]BatchPlaceResult, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		results, err := ex.BatchPlace(ctx, market, reqs, opts)
+		if err == nil {
+			return results, nil
+		}
+
+		if !bd.IsTransient(err) {
+			return nil, err
+		}
+
+		lastErr = err
+		time.Sleep(retryBackoff(attempt))
+	}
+
+	return nil, lastErr
+}
+
+// BatchPlaceOrders handles POST /orders/batch: it places every order in the
+// JSON array body, which must all target the same market, as a single batch.
+// ?atomic=true rejects the whole batch if any order would fail.
+func (ex *Exchange) BatchPlaceOrders(c echo.Context) error {
+	var reqs []md.PlaceOrderRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&reqs); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	if len(reqs) == 0 {
+		return c.JSON(http.StatusOK, []BatchPlaceResult{})
+	}
+
+	market := reqs[0].Market
+	for i := range reqs {
+		if reqs[i].Market != market {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: "all orders in a batch must target the same market"})
+		}
+		if reqs[i].TimeInForce == "" {
+			reqs[i].TimeInForce = md.GTC
+		}
+	}
+
+	opts := BatchPlaceOptions{Atomic: c.QueryParam("atomic") == "true"}
+
+	results, err := ex.BatchPlace(c.Request().Context(), market, reqs, opts)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// BatchCancelResult is the outcome of canceling a single order within a batch.
+type BatchCancelResult struct {
+	ID    int64
+	Error string `json:",omitempty"`
+}
+
+// BatchCancelOrders handles DELETE /orders/batch: it cancels every order ID
+// in the JSON body against a single market.
+func (ex *Exchange) BatchCancelOrders(c echo.Context) error {
+	var body struct {
+		Market md.Market
+		IDs    []int64
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: err.Error()})
+	}
+
+	ex.mu.RLock()
+	ob, ok := ex.bidBk[body.Market]
+	ex.mu.RUnlock()
+	if !ok {
+		return c.JSON(http.StatusBadRequest, md.APIError{Error: "market not found"})
+	}
+
+	results := make([]BatchCancelResult, len(body.IDs))
+	for i, id := range body.IDs {
+		bid, ok := ob.CancelOrderByID(id)
+		if !ok {
+			results[i] = BatchCancelResult{ID: id, Error: "order not found"}
+			continue
+		}
+
+		if ex.risk != nil {
+			ex.risk.OrderClosed(bid.ID)
+		}
+		results[i] = BatchCancelResult{ID: id}
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
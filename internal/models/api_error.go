@@ -0,0 +1,6 @@
+package models
+
+// APIError is the JSON body returned for client-facing error responses.
+type APIError struct {
+	Error string
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// SettlementBackend identifies which settlement mechanism a market uses to
+// move funds between users once a trade executes.
+type SettlementBackend string
+
+const (
+	// SettlementNativeETH settles trades with a native ETH transfer.
+	SettlementNativeETH SettlementBackend = "NATIVE_ETH"
+	// SettlementERC20 settles trades with an ERC-20 token transfer against a bound contract.
+	SettlementERC20 SettlementBackend = "ERC20"
+	// SettlementPaper is a no-op backend, useful for markets without real settlement (e.g. tests).
+	SettlementPaper SettlementBackend = "PAPER"
+)
+
+// MarketConfig describes a tradable market: its base/quote assets, the
+// exchange's minimum increments, and which settlement backend clears its trades.
+type MarketConfig struct {
+	Market       Market
+	BaseAsset    string
+	QuoteAsset   string
+	TickSize     float64
+	LotSize      float64
+	MinNotional  float64
+	Settlement   SettlementBackend
+	ContractAddr string // ERC-20 token contract address; required when Settlement == SettlementERC20
+}
+
+// ValidateOrder rejects a price/size pair that violates cfg's tick size, lot
+// size, or minimum notional. isMarketOrder skips the tick-size and
+// min-notional checks, since a market order's execution price isn't fixed
+// up front; lot size still applies to every order.
+func (cfg MarketConfig) ValidateOrder(price, size float64, isMarketOrder bool) error {
+	if cfg.LotSize > 0 && !isMultipleOf(size, cfg.LotSize) {
+		return fmt.Errorf("size %.8f is not a multiple of market %s lot size %.8f", size, cfg.Market, cfg.LotSize)
+	}
+
+	if isMarketOrder {
+		return nil
+	}
+
+	if cfg.TickSize > 0 && !isMultipleOf(price, cfg.TickSize) {
+		return fmt.Errorf("price %.8f is not a multiple of market %s tick size %.8f", price, cfg.Market, cfg.TickSize)
+	}
+
+	if cfg.MinNotional > 0 && price*size < cfg.MinNotional {
+		return fmt.Errorf("notional %.8f is below market %s minimum notional %.8f", price*size, cfg.Market, cfg.MinNotional)
+	}
+
+	return nil
+}
+
+// isMultipleOf reports whether value is an integer multiple of increment,
+// tolerant of float rounding error. An increment <= 0 disables the check.
+func isMultipleOf(value, increment float64) bool {
+	if increment <= 0 {
+		return true
+	}
+	ratio := value / increment
+	return math.Abs(ratio-math.Round(ratio)) < 1e-8
+}
@@ -1,12 +1,30 @@
 package models
 
+// TimeInForce controls how long an order remains eligible for matching
+// before it is canceled or rejected.
+type TimeInForce string
+
+const (
+	// GTC (good-till-canceled) rests on the book until filled or explicitly canceled. This is the default.
+	GTC TimeInForce = "GTC"
+	// IOC (immediate-or-cancel) fills what it can immediately and cancels any unfilled remainder
+	// rather than resting it on the book.
+	IOC TimeInForce = "IOC"
+	// FOK (fill-or-kill) only executes if the full requested size can be matched atomically;
+	// otherwise the order is rejected in its entirety.
+	FOK TimeInForce = "FOK"
+	// PostOnly rejects the order outright if it would immediately cross the book.
+	PostOnly TimeInForce = "POST_ONLY"
+)
+
 type PlaceOrderRequest struct {
-	UserID int64
-	Type   OrderType // limit or market
-	Bid    bool
-	Size   float64
-	Price  float64
-	Market Market
+	UserID      int64
+	Type        OrderType // limit or market
+	Bid         bool
+	Size        float64
+	Price       float64
+	Market      Market
+	TimeInForce TimeInForce
 }
 
 type Market string
@@ -0,0 +1,46 @@
+package risk
+
+import (
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the risk limits an operator can tune without a rebuild. It is
+// typically loaded from a YAML file via LoadConfig. A zero-value Config
+// enforces no limits.
+type Config struct {
+	// MaxOpenOrdersPerUser caps how many resting orders a single user may have at once. Zero means no limit.
+	MaxOpenOrdersPerUser int `yaml:"maxOpenOrdersPerUser"`
+	// MaxNotionalPerUser caps a user's total open notional exposure (sum of size*price across open orders). Zero means no limit.
+	MaxNotionalPerUser float64 `yaml:"maxNotionalPerUser"`
+	// MaxOrderSizePerMarket caps a single order's size, keyed by market symbol.
+	MaxOrderSizePerMarket map[string]float64 `yaml:"maxOrderSizePerMarket"`
+	// MaxConsecutiveLosses trips a scope's breaker after this many consecutive losing fills. Zero means no limit.
+	MaxConsecutiveLosses int `yaml:"maxConsecutiveLosses"`
+	// MaxRollingLoss trips a scope's breaker once its accumulated loss over RollingWindow exceeds this amount. Zero means no limit.
+	MaxRollingLoss float64 `yaml:"maxRollingLoss"`
+	// RollingWindow bounds how far back MaxRollingLoss looks.
+	RollingWindow time.Duration `yaml:"rollingWindow"`
+	// ReferencePrice is the price each market's fills are compared against to decide whether a fill is a loss, keyed by market symbol.
+	ReferencePrice map[string]float64 `yaml:"referencePrice"`
+	// KillSwitchEngaged seeds the exchange-wide kill switch at startup, halting
+	// every new order until an operator disengages it via SetKillSwitch.
+	KillSwitchEngaged bool `yaml:"killSwitchEngaged"`
+}
+
+// LoadConfig reads and parses a risk Config from a YAML file at path.
+func LoadConfig(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
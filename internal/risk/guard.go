@@ -0,0 +1,258 @@
+package risk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TrippedError indicates a risk scope (the exchange as a whole, a user, or a
+// market) is currently halted and must be reset by an admin before it can
+// trade again.
+type TrippedError struct {
+	Scope  string
+	Reason string
+}
+
+func (e *TrippedError) Error() string {
+	return fmt.Sprintf("risk: %s halted: %s", e.Scope, e.Reason)
+}
+
+// loss tracks consecutive-loss and rolling-loss breaker state for a single scope.
+type loss struct {
+	tripped           bool
+	reason            string
+	consecutiveLosses int
+	windowStart       time.Time
+	windowLoss        float64
+}
+
+// openOrder is the bookkeeping Guard keeps for a single resting order, so its
+// contribution to open-order-count and notional-exposure limits can be
+// released precisely once the order is closed.
+type openOrder struct {
+	userID   int64
+	notional float64
+}
+
+// Guard wraps the matching engine with pluggable pre-trade and post-trade
+// risk checks: per-user max open order count, per-user max notional
+// exposure, per-market max order size, a global kill switch, and a
+// rolling loss-based breaker per user and per market.
+type Guard struct {
+	mu sync.Mutex
+
+	cfg Config
+
+	killSwitch bool
+
+	open       map[int64]*openOrder
+	openOrders map[int64]int
+	notional   map[int64]float64
+
+	userLoss   map[int64]*loss
+	marketLoss map[string]*loss
+}
+
+// NewGuard creates a Guard enforcing cfg's limits, engaging the kill switch
+// up front if cfg.KillSwitchEngaged is set.
+func NewGuard(cfg Config) *Guard {
+	return &Guard{
+		cfg:        cfg,
+		killSwitch: cfg.KillSwitchEngaged,
+		open:       make(map[int64]*openOrder),
+		openOrders: make(map[int64]int),
+		notional:   make(map[int64]float64),
+		userLoss:   make(map[int64]*loss),
+		marketLoss: make(map[string]*loss),
+	}
+}
+
+// SetKillSwitch halts (or resumes) new orders exchange-wide.
+func (g *Guard) SetKillSwitch(on bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.killSwitch = on
+}
+
+// PreTradeCheck rejects an incoming order if its scope (the exchange, the
+// user, or the market) is currently tripped, or if it would breach a
+// configured limit. For a market order, availableLiquidity is the volume
+// currently resting on the side of the book it would match against (e.g. the
+// ask side for a market buy); a market order larger than that is rejected
+// here instead of reaching the matching engine, which panics rather than
+// partially filling a non-FOK market order it can't cover. availableLiquidity
+// is ignored for limit orders.
+func (g *Guard) PreTradeCheck(userID int64, market string, size, price float64, isMarketOrder bool, availableLiquidity float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.killSwitch {
+		return &TrippedError{Scope: "exchange", Reason: "kill switch engaged"}
+	}
+
+	if l, ok := g.userLoss[userID]; ok && l.tripped {
+		return &TrippedError{Scope: fmt.Sprintf("user:%d", userID), Reason: l.reason}
+	}
+
+	if l, ok := g.marketLoss[market]; ok && l.tripped {
+		return &TrippedError{Scope: fmt.Sprintf("market:%s", market), Reason: l.reason}
+	}
+
+	if limit, ok := g.cfg.MaxOrderSizePerMarket[market]; ok && limit > 0 && size > limit {
+		return fmt.Errorf("risk: order size %.4f exceeds market %s max order size %.4f", size, market, limit)
+	}
+
+	if isMarketOrder && size > availableLiquidity {
+		return fmt.Errorf("risk: market order size %.4f exceeds available liquidity %.4f in market %s", size, availableLiquidity, market)
+	}
+
+	if g.cfg.MaxOpenOrdersPerUser > 0 && g.openOrders[userID] >= g.cfg.MaxOpenOrdersPerUser {
+		return fmt.Errorf("risk: user %d has reached the max open order count (%d)", userID, g.cfg.MaxOpenOrdersPerUser)
+	}
+
+	if g.cfg.MaxNotionalPerUser > 0 && g.notional[userID]+size*price > g.cfg.MaxNotionalPerUser {
+		return fmt.Errorf("risk: user %d would exceed the max notional exposure (%.2f)", userID, g.cfg.MaxNotionalPerUser)
+	}
+
+	return nil
+}
+
+// OrderAccepted records that orderID is now resting on the book for userID,
+// so it counts toward that user's open-order-count and notional-exposure limits.
+func (g *Guard) OrderAccepted(orderID, userID int64, size, price float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	notional := size * price
+	g.open[orderID] = &openOrder{userID: userID, notional: notional}
+	g.openOrders[userID]++
+	g.notional[userID] += notional
+}
+
+// OrderClosed records that orderID is no longer resting (filled or
+// canceled), releasing its contribution to open-order-count and
+// notional-exposure limits. It is a no-op for order IDs Guard never saw,
+// e.g. orders placed while no Guard was configured.
+func (g *Guard) OrderClosed(orderID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	o, ok := g.open[orderID]
+	if !ok {
+		return
+	}
+	delete(g.open, orderID)
+
+	if g.openOrders[o.userID] > 0 {
+		g.openOrders[o.userID]--
+	}
+	g.notional[o.userID] -= o.notional
+}
+
+// RecordFill updates the rolling-loss breaker for both userID and market
+// after a fill. A fill is a loss when it executes on the wrong side of
+// market's configured reference price: a bid filled above it, or an ask
+// filled below it. RecordFill is a no-op for markets without a reference price configured.
+func (g *Guard) RecordFill(userID int64, market string, bid bool, size, price float64) {
+	ref, ok := g.cfg.ReferencePrice[market]
+	if !ok {
+		return
+	}
+
+	lossAmount := 0.0
+	switch {
+	case bid && price > ref:
+		lossAmount = size * (price - ref)
+	case !bid && price < ref:
+		lossAmount = size * (ref - price)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ul, ok := g.userLoss[userID]
+	if !ok {
+		ul = &loss{windowStart: time.Now()}
+		g.userLoss[userID] = ul
+	}
+	g.applyLoss(ul, lossAmount)
+
+	ml, ok := g.marketLoss[market]
+	if !ok {
+		ml = &loss{windowStart: time.Now()}
+		g.marketLoss[market] = ml
+	}
+	g.applyLoss(ml, lossAmount)
+}
+
+// applyLoss folds a single fill's loss into l, tripping it once it crosses
+// the configured consecutive-loss or rolling-loss limit. Callers must hold g.mu.
+func (g *Guard) applyLoss(l *loss, amount float64) {
+	if amount <= 0 {
+		l.consecutiveLosses = 0
+	} else {
+		l.consecutiveLosses++
+	}
+
+	now := time.Now()
+	if g.cfg.RollingWindow > 0 && now.Sub(l.windowStart) > g.cfg.RollingWindow {
+		l.windowStart = now
+		l.windowLoss = 0
+	}
+	l.windowLoss += amount
+
+	if g.cfg.MaxConsecutiveLosses > 0 && l.consecutiveLosses >= g.cfg.MaxConsecutiveLosses {
+		l.tripped = true
+		l.reason = fmt.Sprintf("%d consecutive losing fills", l.consecutiveLosses)
+	}
+
+	if g.cfg.MaxRollingLoss > 0 && l.windowLoss > g.cfg.MaxRollingLoss {
+		l.tripped = true
+		l.reason = fmt.Sprintf("rolling loss %.2f exceeded max %.2f", l.windowLoss, g.cfg.MaxRollingLoss)
+	}
+}
+
+// Reset clears the tripped breaker for scope ("exchange", "user:<id>", or
+// "market:<symbol>"), restoring normal trading for it.
+func (g *Guard) Reset(scope string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if scope == "exchange" {
+		g.killSwitch = false
+		for _, l := range g.userLoss {
+			resetLoss(l)
+		}
+		for _, l := range g.marketLoss {
+			resetLoss(l)
+		}
+		return nil
+	}
+
+	var userID int64
+	if _, err := fmt.Sscanf(scope, "user:%d", &userID); err == nil {
+		if l, ok := g.userLoss[userID]; ok {
+			resetLoss(l)
+		}
+		return nil
+	}
+
+	var market string
+	if _, err := fmt.Sscanf(scope, "market:%s", &market); err == nil {
+		if l, ok := g.marketLoss[market]; ok {
+			resetLoss(l)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("risk: unrecognized scope %q", scope)
+}
+
+func resetLoss(l *loss) {
+	l.tripped = false
+	l.reason = ""
+	l.consecutiveLosses = 0
+	l.windowLoss = 0
+	l.windowStart = time.Now()
+}
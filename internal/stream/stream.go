@@ -0,0 +1,186 @@
+package stream
+
+import (
+	"net/http"
+	"time"
+
+	bd "github.com/echenim/barter/internal/bidder"
+	hdl "github.com/echenim/barter/internal/handlers"
+	md "github.com/echenim/barter/internal/models"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+// writeDeadline bounds how long a single WS frame write may block. A
+// subscriber that can't keep up within this window is disconnected rather
+// than allowed to stall the publisher.
+const writeDeadline = 5 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// BookSnapshot is the first frame sent on a /ws/book/:market connection: a
+// full L2 snapshot tagged with the sequence number it was taken at. Every
+// frame after it is a BookDelta carrying a monotonically increasing sequence
+// so clients can detect gaps and request a fresh snapshot.
+type BookSnapshot struct {
+	Seq  uint64
+	Book md.BookedBidData
+}
+
+// BookDelta is a single incremental book event.
+type BookDelta struct {
+	Seq       uint64
+	Type      bd.EventType
+	Bid       bool
+	Price     float64
+	Size      float64
+	OrderID   int64
+	Timestamp int64
+}
+
+// TradeEvent is a single executed trade.
+type TradeEvent struct {
+	Seq       uint64
+	Price     float64
+	Size      float64
+	Bid       bool
+	Timestamp int64
+}
+
+// RegisterRoutes mounts the book and trade WebSocket streams on e, backed by ex.
+func RegisterRoutes(e *echo.Echo, ex *hdl.Exchange) {
+	e.GET("/ws/book/:market", bookStreamHandler(ex))
+	e.GET("/ws/trades/:market", tradeStreamHandler(ex))
+}
+
+// bookStreamHandler streams a full L2 snapshot followed by incremental book
+// events (OrderAdded, OrderCanceled, LimitCleared, TradeExecuted) for a market.
+func bookStreamHandler(ex *hdl.Exchange) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ob, ok := ex.Book(md.Market(c.Param("market")))
+		if !ok {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: "market not found"})
+		}
+
+		conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		events, unsubscribe, seq, asks, bids := ob.SubscribeWithSnapshot()
+		defer unsubscribe()
+
+		snapshot := BookSnapshot{Seq: seq, Book: snapshotBook(asks, bids)}
+		if err := writeJSON(conn, snapshot); err != nil {
+			return nil
+		}
+
+		for evt := range events {
+			delta := BookDelta{
+				Seq:       evt.Seq,
+				Type:      evt.Type,
+				Bid:       evt.Bid,
+				Price:     evt.Price,
+				Size:      evt.Size,
+				OrderID:   evt.OrderID,
+				Timestamp: evt.Timestamp,
+			}
+			if err := writeJSON(conn, delta); err != nil {
+				return nil
+			}
+		}
+
+		return nil
+	}
+}
+
+// tradeStreamHandler streams executed trades for a market.
+func tradeStreamHandler(ex *hdl.Exchange) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ob, ok := ex.Book(md.Market(c.Param("market")))
+		if !ok {
+			return c.JSON(http.StatusBadRequest, md.APIError{Error: "market not found"})
+		}
+
+		conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		events, unsubscribe := ob.Subscribe()
+		defer unsubscribe()
+
+		for evt := range events {
+			if evt.Type != bd.EventTradeExecuted {
+				continue
+			}
+
+			trade := TradeEvent{
+				Seq:       evt.Seq,
+				Price:     evt.Price,
+				Size:      evt.Size,
+				Bid:       evt.Bid,
+				Timestamp: evt.Timestamp,
+			}
+			if err := writeJSON(conn, trade); err != nil {
+				return nil
+			}
+		}
+
+		return nil
+	}
+}
+
+// writeJSON writes v to conn, bounding the write with writeDeadline so a
+// stalled client can't block the caller indefinitely.
+func writeJSON(conn *websocket.Conn, v any) error {
+	conn.SetWriteDeadline(time.Now().Add(writeDeadline))
+	return conn.WriteJSON(v)
+}
+
+// snapshotBook builds a full L2 snapshot from asks and bids, mirroring
+// handlers.Exchange.GetBook. The caller is responsible for taking asks and
+// bids at a single, consistent point in the book's history (see
+// bidder.BookBid.SubscribeWithSnapshot).
+func snapshotBook(asks, bids []bd.LimitSnapshot) md.BookedBidData {
+	data := md.BookedBidData{
+		Asks: []*md.Order{},
+		Bids: []*md.Order{},
+	}
+
+	for _, limit := range asks {
+		data.TotalAskVolume += limit.TotalVolume
+		for _, order := range limit.Orders {
+			data.Asks = append(data.Asks, &md.Order{
+				UserID:    order.UserID,
+				ID:        order.ID,
+				Price:     limit.Price,
+				Size:      order.Size,
+				Bid:       order.Bid,
+				Timestamp: order.Timestamp,
+			})
+		}
+	}
+
+	for _, limit := range bids {
+		data.TotalBidVolume += limit.TotalVolume
+		for _, order := range limit.Orders {
+			data.Bids = append(data.Bids, &md.Order{
+				UserID:    order.UserID,
+				ID:        order.ID,
+				Price:     limit.Price,
+				Size:      order.Size,
+				Bid:       order.Bid,
+				Timestamp: order.Timestamp,
+			})
+		}
+	}
+
+	return data
+}